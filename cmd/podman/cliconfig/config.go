@@ -0,0 +1,33 @@
+package cliconfig
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// MainFlags are the persistent, global flags parsed on the root podman
+// command and copied onto every subcommand's PodmanCommand.
+type MainFlags struct {
+	CGroupManager     string
+	CniConfigDir      string
+	ConmonPath        string
+	DefaultMountsFile string
+	HooksDir          []string
+	LogLevel          string
+	Namespace         string
+	Root              string
+	Runroot           string
+	Runtime           string
+	StorageDriver     string
+	StorageOpts       []string
+	Syslog            bool
+}
+
+// PodmanCommand is embedded by every subcommand's Values struct. RunE stores
+// the parsed global flags and raw positional arguments here before handing
+// off to the command's implementation function.
+type PodmanCommand struct {
+	*cobra.Command
+	InputArgs   []string
+	GlobalFlags MainFlags
+	Remote      bool
+}
@@ -0,0 +1,16 @@
+package cliconfig
+
+// SaveValues holds the parsed flags and positional arguments for
+// `podman save`.
+type SaveValues struct {
+	PodmanCommand
+	Compress          bool
+	Format            string
+	MultiImageArchive bool
+	Output            string
+	Quiet             bool
+	CompressFormat    string
+	CompressLevel     int
+	SplitSize         string
+	Progress          string
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/containers/image/directory"
@@ -11,10 +15,13 @@ import (
 	"github.com/containers/image/docker/reference"
 	"github.com/containers/image/manifest"
 	ociarchive "github.com/containers/image/oci/archive"
+	"github.com/containers/image/pkg/compression"
 	"github.com/containers/image/types"
 	"github.com/containers/libpod/cmd/podman/cliconfig"
 	"github.com/containers/libpod/cmd/podman/libpodruntime"
+	"github.com/containers/libpod/libpod"
 	libpodImage "github.com/containers/libpod/libpod/image"
+	units "github.com/docker/go-units"
 	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -50,8 +57,13 @@ func init() {
 	flags := saveCommand.Flags()
 	flags.BoolVar(&saveCommand.Compress, "compress", false, "Compress tarball image layers when saving to a directory using the 'dir' transport. (default is same compression type as source)")
 	flags.StringVar(&saveCommand.Format, "format", "", "Save image to oci-archive, oci-dir (directory with oci manifest type), docker-dir (directory with v2s2 manifest type)")
+	flags.BoolVarP(&saveCommand.MultiImageArchive, "multi-image-archive", "m", false, "Force additional arguments to be interpreted as distinct images, not tags, and bundle them into a single docker-archive or oci-archive (auto-detected by default)")
 	flags.StringVarP(&saveCommand.Output, "output", "o", "/dev/stdout", "Write to a file, default is STDOUT")
 	flags.BoolVarP(&saveCommand.Quiet, "quiet", "q", false, "Suppress the output")
+	flags.StringVar(&saveCommand.CompressFormat, "compress-format", "", "Compress tarball image layers using the given format (gzip, zstd)")
+	flags.IntVar(&saveCommand.CompressLevel, "compression-level", -1, "Compression level to use when --compress-format is set (default is the format's default level)")
+	flags.StringVar(&saveCommand.SplitSize, "split-size", "", "Split the resulting archive into parts of at most this size (e.g. 2G), writing output.part0, output.part1, ...")
+	flags.StringVar(&saveCommand.Progress, "progress", "plain", "Display progress as 'plain' human-readable text, 'json' (one object per line), or 'none'")
 }
 
 // saveCmd saves the image to either docker-archive or oci
@@ -61,19 +73,62 @@ func saveCmd(c *cliconfig.SaveValues) error {
 		return errors.Errorf("need at least 1 argument")
 	}
 
+	formatSupportsMultiImage := c.Format == "" || c.Format == "docker-archive" || c.Format == "oci-archive"
+	if c.MultiImageArchive && !formatSupportsMultiImage {
+		return errors.Errorf("--multi-image-archive is only supported when saving to the docker-archive or oci-archive format")
+	}
+
 	runtime, err := libpodruntime.GetRuntime(&c.PodmanCommand)
 	if err != nil {
 		return errors.Wrapf(err, "could not create runtime")
 	}
 	defer runtime.Shutdown(false)
 
+	// Match `docker save img1 img2`: treat args as distinct images, not
+	// img1's additional tags, whenever they actually resolve to distinct
+	// images - not just when --multi-image-archive was passed explicitly.
+	multiImage := c.MultiImageArchive
+	if !multiImage && len(args) > 1 && formatSupportsMultiImage {
+		multiImage = areDistinctLocalImages(runtime, args)
+	}
+
 	if c.Flag("compress").Changed && (c.Format != ociManifestDir && c.Format != v2s2ManifestDir && c.Format == "") {
 		return errors.Errorf("--compress can only be set when --format is either 'oci-dir' or 'docker-dir'")
 	}
 
+	var compressionFormat *compression.Algorithm
+	if c.Flag("compress-format").Changed {
+		algo, err := parseSaveCompressionFormat(c.CompressFormat)
+		if err != nil {
+			return err
+		}
+		compressionFormat = &algo
+	}
+	var compressionLevel *int
+	if c.Flag("compression-level").Changed {
+		if compressionFormat == nil {
+			return errors.Errorf("--compression-level can only be used together with --compress-format")
+		}
+		level := c.CompressLevel
+		compressionLevel = &level
+	}
+	sys := &types.SystemContext{
+		CompressionFormat: compressionFormat,
+		CompressionLevel:  compressionLevel,
+	}
+
 	var writer io.Writer
 	if !c.Quiet {
-		writer = os.Stderr
+		switch c.Progress {
+		case "plain":
+			writer = os.Stderr
+		case "json":
+			writer = newProgressJSONWriter(os.Stderr)
+		case "none":
+			writer = nil
+		default:
+			return errors.Errorf("unknown --progress option %q: must be one of plain, json, none", c.Progress)
+		}
 	}
 
 	output := c.Output
@@ -87,63 +142,232 @@ func saveCmd(c *cliconfig.SaveValues) error {
 		return err
 	}
 
-	source := args[0]
-	newImage, err := runtime.ImageRuntime().NewFromLocal(source)
+	// args[1:] are either additional tags for args[0], or (when multiImage
+	// is set, explicitly via --multi-image-archive or by auto-detection)
+	// additional images to bundle into the same archive - in the latter
+	// case args[0] is resolved and referenced inside saveMultiImageArchive
+	// like every other name, so there's no need to look it up or build a
+	// destRef for it here too.
+	if multiImage {
+		if err := saveMultiImageArchive(getContext(), runtime, args, c.Format, output, writer, c.Bool("compress"), sys); err != nil {
+			if err2 := os.Remove(output); err2 != nil {
+				logrus.Errorf("error deleting %q: %v", output, err)
+			}
+			return errors.Wrapf(err, "unable to save %q", args)
+		}
+	} else {
+		source := args[0]
+		newImage, err := runtime.ImageRuntime().NewFromLocal(source)
+		if err != nil {
+			return err
+		}
+
+		var destRef types.ImageReference
+		var manifestType string
+		switch c.Format {
+		case "oci-archive":
+			destImageName := imageNameForSaveDestination(newImage, source)
+			destRef, err = ociarchive.NewReference(output, destImageName) // destImageName may be ""
+			if err != nil {
+				return errors.Wrapf(err, "error getting OCI archive ImageReference for (%q, %q)", output, destImageName)
+			}
+		case "oci-dir":
+			destRef, err = directory.NewReference(output)
+			if err != nil {
+				return errors.Wrapf(err, "error getting directory ImageReference for %q", output)
+			}
+			manifestType = imgspecv1.MediaTypeImageManifest
+		case "docker-dir":
+			destRef, err = directory.NewReference(output)
+			if err != nil {
+				return errors.Wrapf(err, "error getting directory ImageReference for %q", output)
+			}
+			manifestType = manifest.DockerV2Schema2MediaType
+		case "docker-archive", "":
+			dst := output
+			destImageName := imageNameForSaveDestination(newImage, source)
+			if destImageName != "" {
+				dst = fmt.Sprintf("%s:%s", dst, destImageName)
+			}
+			destRef, err = dockerarchive.ParseReference(dst) // FIXME? Add dockerarchive.NewReference
+			if err != nil {
+				return errors.Wrapf(err, "error getting Docker archive ImageReference for %q", dst)
+			}
+		default:
+			return errors.Errorf("unknown format option %q", c.String("format"))
+		}
+
+		var additionaltags []reference.NamedTagged
+		if len(args) > 1 {
+			additionaltags, err = libpodImage.GetAdditionalTags(args[1:])
+			if err != nil {
+				return err
+			}
+		}
+		if err := newImage.PushImageToReference(getContext(), destRef, manifestType, "", "", writer, c.Bool("compress"), libpodImage.SigningOptions{}, &libpodImage.DockerRegistryOptions{}, additionaltags, sys); err != nil {
+			if err2 := os.Remove(output); err2 != nil {
+				logrus.Errorf("error deleting %q: %v", output, err)
+			}
+			return errors.Wrapf(err, "unable to save %q", args)
+		}
+	}
+
+	if c.Flag("split-size").Changed {
+		if output == "/dev/stdout" {
+			return errors.Errorf("--split-size cannot be used when writing to STDOUT")
+		}
+		sizeInBytes, err := units.RAMInBytes(c.SplitSize)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing --split-size %q", c.SplitSize)
+		}
+		if sizeInBytes <= 0 {
+			return errors.Errorf("--split-size %q must be a positive size", c.SplitSize)
+		}
+		if err := splitArchive(output, sizeInBytes); err != nil {
+			return errors.Wrapf(err, "error splitting %q into %q parts", output, c.SplitSize)
+		}
+	}
+
+	return nil
+}
+
+// areDistinctLocalImages reports whether args name more than one distinct
+// local image, the way `docker save img1 img2` expects, rather than args[1:]
+// being plain additional tags for args[0]. Any name that doesn't resolve to
+// a local image at all is treated as a tag, not a distinct image, so that
+// names like "latest" keep working as tags when they aren't themselves valid
+// image references.
+func areDistinctLocalImages(runtime *libpod.Runtime, args []string) bool {
+	first, err := runtime.ImageRuntime().NewFromLocal(args[0])
 	if err != nil {
-		return err
+		return false
+	}
+	for _, name := range args[1:] {
+		img, err := runtime.ImageRuntime().NewFromLocal(name)
+		if err == nil && img.ID() != first.ID() {
+			return true
+		}
 	}
+	return false
+}
+
+// saveMultiImageArchive copies each of names into a single docker-archive or
+// oci-archive tarball at output, the way `docker save img1 img2` would,
+// instead of treating names[1:] as additional tags for names[0].
+func saveMultiImageArchive(ctx context.Context, runtime *libpod.Runtime, names []string, format, output string, writer io.Writer, compress bool, sys *types.SystemContext) error {
+	var newReference func(destImageName string) (types.ImageReference, error)
 
-	var destRef types.ImageReference
-	var manifestType string
-	switch c.Format {
+	switch format {
 	case "oci-archive":
-		destImageName := imageNameForSaveDestination(newImage, source)
-		destRef, err = ociarchive.NewReference(output, destImageName) // destImageName may be ""
+		archiveWriter, err := ociarchive.NewWriter(ctx, sys, output)
 		if err != nil {
-			return errors.Wrapf(err, "error getting OCI archive ImageReference for (%q, %q)", output, destImageName)
+			return errors.Wrapf(err, "error creating OCI archive writer for %q", output)
 		}
-	case "oci-dir":
-		destRef, err = directory.NewReference(output)
+		defer archiveWriter.Close()
+		newReference = archiveWriter.NewReference
+	case "docker-archive", "":
+		archiveWriter, err := dockerarchive.NewWriter(ctx, sys, output)
 		if err != nil {
-			return errors.Wrapf(err, "error getting directory ImageReference for %q", output)
+			return errors.Wrapf(err, "error creating docker-archive writer for %q", output)
 		}
-		manifestType = imgspecv1.MediaTypeImageManifest
-	case "docker-dir":
-		destRef, err = directory.NewReference(output)
+		defer archiveWriter.Close()
+		newReference = archiveWriter.NewReference
+	default:
+		return errors.Errorf("--multi-image-archive is only supported when saving to the docker-archive or oci-archive format")
+	}
+
+	for _, name := range names {
+		img, err := runtime.ImageRuntime().NewFromLocal(name)
 		if err != nil {
-			return errors.Wrapf(err, "error getting directory ImageReference for %q", output)
+			return errors.Wrapf(err, "error looking up %q", name)
 		}
-		manifestType = manifest.DockerV2Schema2MediaType
-	case "docker-archive", "":
-		dst := output
-		destImageName := imageNameForSaveDestination(newImage, source)
-		if destImageName != "" {
-			dst = fmt.Sprintf("%s:%s", dst, destImageName)
-		}
-		destRef, err = dockerarchive.ParseReference(dst) // FIXME? Add dockerarchive.NewReference
+		destImageName := imageNameForSaveDestination(img, name)
+		destRef, err := newReference(destImageName)
 		if err != nil {
-			return errors.Wrapf(err, "error getting Docker archive ImageReference for %q", dst)
+			return errors.Wrapf(err, "error getting ImageReference for %q", name)
+		}
+		if err := img.PushImageToReference(ctx, destRef, "", "", "", writer, compress, libpodImage.SigningOptions{}, &libpodImage.DockerRegistryOptions{}, nil, sys); err != nil {
+			return errors.Wrapf(err, "error saving %q", name)
 		}
+	}
+
+	return nil
+}
+
+// parseSaveCompressionFormat validates the --compress-format value and maps
+// it to the compression.Algorithm used by c/image when writing the archive.
+func parseSaveCompressionFormat(format string) (compression.Algorithm, error) {
+	switch format {
+	case "gzip":
+		return compression.Gzip, nil
+	case "zstd":
+		return compression.Zstd, nil
 	default:
-		return errors.Errorf("unknown format option %q", c.String("format"))
+		return compression.Algorithm{}, errors.Errorf("unknown --compress-format %q: must be one of gzip, zstd", format)
+	}
+}
+
+// splitArchive splits the file at output into sequential parts of at most
+// sizeInBytes each, named output.part0, output.part1, ..., and removes the
+// original file. This is meant for moving very large archives across media
+// with a fixed size limit (e.g. air-gapped transfers). A non-positive
+// sizeInBytes is rejected and output is left untouched.
+func splitArchive(output string, sizeInBytes int64) error {
+	if sizeInBytes <= 0 {
+		return errors.Errorf("invalid split size %d: must be positive", sizeInBytes)
+	}
+	in, err := os.Open(output)
+	if err != nil {
+		return err
 	}
+	defer in.Close()
 
-	// supports saving multiple tags to the same tar archive
-	var additionaltags []reference.NamedTagged
-	if len(args) > 1 {
-		additionaltags, err = libpodImage.GetAdditionalTags(args[1:])
+	buf := make([]byte, 1024*1024)
+	for part := 0; ; part++ {
+		partPath := fmt.Sprintf("%s.part%d", output, part)
+		out, err := os.Create(partPath)
 		if err != nil {
 			return err
 		}
-	}
-	if err := newImage.PushImageToReference(getContext(), destRef, manifestType, "", "", writer, c.Bool("compress"), libpodImage.SigningOptions{}, &libpodImage.DockerRegistryOptions{}, additionaltags); err != nil {
-		if err2 := os.Remove(output); err2 != nil {
-			logrus.Errorf("error deleting %q: %v", output, err)
+
+		var written int64
+		var done bool
+		for written < sizeInBytes {
+			toRead := int64(len(buf))
+			if remaining := sizeInBytes - written; remaining < toRead {
+				toRead = remaining
+			}
+			n, readErr := in.Read(buf[:toRead])
+			if n > 0 {
+				if _, err := out.Write(buf[:n]); err != nil {
+					out.Close()
+					return err
+				}
+				written += int64(n)
+			}
+			if readErr == io.EOF {
+				done = true
+				break
+			}
+			if readErr != nil {
+				out.Close()
+				return readErr
+			}
+		}
+		out.Close()
+
+		if written == 0 {
+			if err := os.Remove(partPath); err != nil {
+				logrus.Errorf("error removing empty archive part %q: %v", partPath, err)
+			}
+			break
+		}
+		if done {
+			break
 		}
-		return errors.Wrapf(err, "unable to save %q", args)
 	}
 
-	return nil
+	return os.Remove(output)
 }
 
 // imageNameForSaveDestination returns a Docker-like reference appropriate for saving img,
@@ -170,3 +394,69 @@ func imageNameForSaveDestination(img *libpodImage.Image, imgUserInput string) st
 	}
 	return fmt.Sprintf("%s%s", prepend, imgUserInput)
 }
+
+// progressEvent is the shape written to the --progress=json stream, one
+// object per line, mirroring the per-layer progress that PushImageToReference
+// otherwise renders as a human-readable progress bar.
+//
+// c/image's copy.Options.ReportWriter (the writer PushImageToReference passes
+// progress through) only ever receives single status lines like "Copying
+// blob sha256:<digest>" - it does not carry byte counts, those are only drawn
+// to a terminal through a separate progress-bar path. So Current/Total stay
+// 0 here; only the layer digest and status phase are available.
+type progressEvent struct {
+	Layer   string `json:"layer"`
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+	Status  string `json:"status"`
+}
+
+// progressLineRegexp matches the "Copying blob/config <digest>" status lines
+// that c/image's ReportWriter actually writes.
+var progressLineRegexp = regexp.MustCompile(`^Copying (blob|config) (sha256:[0-9a-f]+)`)
+
+// progressJSONWriter wraps an io.Writer, translating the freeform progress
+// text written by PushImageToReference into one JSON object per line, per
+// the --progress=json flag on podman save.
+type progressJSONWriter struct {
+	dest io.Writer
+	buf  bytes.Buffer
+}
+
+func newProgressJSONWriter(dest io.Writer) *progressJSONWriter {
+	return &progressJSONWriter{dest: dest}
+}
+
+// Write buffers b and emits one JSON event per complete line it contains,
+// keeping any trailing partial line buffered until it's completed by a later
+// Write instead of flushing it early.
+func (p *progressJSONWriter) Write(b []byte) (int, error) {
+	p.buf.Write(b)
+	for {
+		line, err := p.buf.ReadString('\n')
+		if err != nil {
+			// No newline yet: line is the unterminated remainder. Put it
+			// back so the next Write can complete it.
+			p.buf.Reset()
+			p.buf.WriteString(line)
+			break
+		}
+		p.emit(strings.TrimRight(line, "\n"))
+	}
+	return len(b), nil
+}
+
+func (p *progressJSONWriter) emit(line string) {
+	event := progressEvent{Status: line}
+	if m := progressLineRegexp.FindStringSubmatch(line); m != nil {
+		event.Layer = m[2]
+		event.Status = "copying-" + m[1]
+	}
+
+	out, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("error marshaling save progress event: %v", err)
+		return
+	}
+	fmt.Fprintln(p.dest, string(out))
+}
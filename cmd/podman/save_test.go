@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/pkg/compression"
+)
+
+func TestParseSaveCompressionFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    compression.Algorithm
+		wantErr bool
+	}{
+		{format: "gzip", want: compression.Gzip},
+		{format: "zstd", want: compression.Zstd},
+		{format: "xz", wantErr: true},
+		{format: "bogus", wantErr: true},
+		{format: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSaveCompressionFormat(tt.format)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSaveCompressionFormat(%q): expected an error, got nil", tt.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSaveCompressionFormat(%q): unexpected error: %v", tt.format, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSaveCompressionFormat(%q) = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestSplitArchive(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "archive.tar")
+	contents := bytes.Repeat([]byte("podman-save-test-data"), 1000)
+	if err := ioutil.WriteFile(output, contents, 0o644); err != nil {
+		t.Fatalf("writing fixture archive: %v", err)
+	}
+
+	const partSize = 4096
+	if err := splitArchive(output, partSize); err != nil {
+		t.Fatalf("splitArchive: %v", err)
+	}
+
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Fatalf("expected %q to be removed after splitting, stat err = %v", output, err)
+	}
+
+	var reassembled []byte
+	for part := 0; ; part++ {
+		partPath := fmt.Sprintf("%s.part%d", output, part)
+		data, err := ioutil.ReadFile(partPath)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading %q: %v", partPath, err)
+		}
+		if part < len(contents)/partSize && len(data) != partSize {
+			t.Errorf("part %d: got %d bytes, want %d", part, len(data), partSize)
+		}
+		reassembled = append(reassembled, data...)
+	}
+
+	if !bytes.Equal(reassembled, contents) {
+		t.Fatalf("reassembled parts do not match original contents (got %d bytes, want %d)", len(reassembled), len(contents))
+	}
+}
+
+func TestSplitArchiveRejectsNonPositiveSize(t *testing.T) {
+	// saveCmd validates --split-size before calling splitArchive, but
+	// splitArchive also rejects a non-positive size itself instead of
+	// deleting the archive without writing any parts.
+	dir := t.TempDir()
+	output := filepath.Join(dir, "archive.tar")
+	if err := ioutil.WriteFile(output, []byte("some data"), 0o644); err != nil {
+		t.Fatalf("writing fixture archive: %v", err)
+	}
+
+	if err := splitArchive(output, 0); err == nil {
+		t.Fatalf("splitArchive(0): expected an error, got nil")
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Fatalf("splitArchive(0) silently deleted %q without writing any replacement parts", output)
+	}
+}
+
+func TestProgressJSONWriterEmitsOneEventPerLine(t *testing.T) {
+	var dest bytes.Buffer
+	w := newProgressJSONWriter(&dest)
+
+	fmt.Fprintln(w, "Getting image source signatures")
+	fmt.Fprintln(w, "Copying blob sha256:abcd1234")
+	fmt.Fprintln(w, "Copying config sha256:ef567890")
+	fmt.Fprintln(w, "Writing manifest to image destination")
+
+	var events []progressEvent
+	dec := json.NewDecoder(&dest)
+	for dec.More() {
+		var e progressEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("decoding progress event: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4: %+v", len(events), events)
+	}
+	if events[1].Layer != "sha256:abcd1234" || events[1].Status != "copying-blob" {
+		t.Errorf("blob event = %+v, want layer sha256:abcd1234, status copying-blob", events[1])
+	}
+	if events[2].Layer != "sha256:ef567890" || events[2].Status != "copying-config" {
+		t.Errorf("config event = %+v, want layer sha256:ef567890, status copying-config", events[2])
+	}
+	if events[0].Layer != "" || events[0].Status != "Getting image source signatures" {
+		t.Errorf("unmatched line event = %+v, want passthrough status", events[0])
+	}
+}
+
+func TestProgressJSONWriterBuffersPartialLines(t *testing.T) {
+	var dest bytes.Buffer
+	w := newProgressJSONWriter(&dest)
+
+	// Write the same logical line split across two Write calls, as a
+	// fragmented io.Writer chain would. It must not be emitted until the
+	// newline arrives.
+	if _, err := w.Write([]byte("Copying blob sha256:")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if dest.Len() != 0 {
+		t.Fatalf("expected no output before the line is terminated, got %q", dest.String())
+	}
+	if _, err := w.Write([]byte("deadbeef\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var event progressEvent
+	if err := json.Unmarshal(bytes.TrimSpace(dest.Bytes()), &event); err != nil {
+		t.Fatalf("decoding progress event: %v", err)
+	}
+	if event.Layer != "sha256:deadbeef" {
+		t.Errorf("event.Layer = %q, want sha256:deadbeef", event.Layer)
+	}
+}
@@ -1,14 +1,19 @@
 package system
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"text/template"
 
+	"github.com/BurntSushi/toml"
+	"github.com/PaesslerAG/jsonpath"
 	"github.com/containers/libpod/cmd/podman/common"
 	"github.com/containers/libpod/cmd/podman/registry"
 	"github.com/containers/libpod/pkg/domain/entities"
 	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +35,8 @@ var (
 var (
 	inFormat string
 	debug    bool
+	jsonPath string
+	fields   []string
 )
 
 func init() {
@@ -39,7 +46,9 @@ func init() {
 	})
 	flags := infoCommand.Flags()
 	flags.BoolVarP(&debug, "debug", "D", false, "Display additional debug information")
-	flags.StringVarP(&inFormat, "format", "f", "", "Change the output format to JSON or a Go template")
+	flags.StringVarP(&inFormat, "format", "f", "", "Change the output format to json, toml, or a Go template")
+	flags.StringVar(&jsonPath, "jsonpath", "", "Filter output using a JSONPath expression")
+	flags.StringSliceVar(&fields, "fields", nil, "Project only these dotted field paths from the output (e.g. host.kernel,store.graphDriverName)")
 }
 
 func info(cmd *cobra.Command, args []string) error {
@@ -48,13 +57,24 @@ func info(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if inFormat == "json" {
+	if cmd.Flag("jsonpath").Changed || cmd.Flag("fields").Changed {
+		return printFiltered(info, jsonPath, fields)
+	}
+
+	switch inFormat {
+	case "json":
 		b, err := json.MarshalIndent(info, "", "  ")
 		if err != nil {
 			return err
 		}
 		fmt.Println(string(b))
 		return nil
+	case "toml":
+		data, err := toGenericMap(info)
+		if err != nil {
+			return err
+		}
+		return toml.NewEncoder(os.Stdout).Encode(data)
 	}
 	if !cmd.Flag("format").Changed {
 		b, err := yaml.Marshal(info)
@@ -71,3 +91,71 @@ func info(cmd *cobra.Command, args []string) error {
 	err = tmpl.Execute(os.Stdout, info)
 	return err
 }
+
+// toGenericMap round-trips v through JSON so every output format (json,
+// toml, and --jsonpath/--fields) agrees on the same field names: the
+// struct's `json` tags, rather than toml.Encode's default of raw Go field
+// names. lookupField only walks the resulting maps, so it needs this too.
+func toGenericMap(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// printFiltered renders info through --jsonpath and/or --fields, whichever
+// was given; --jsonpath takes precedence if both are set.
+func printFiltered(info interface{}, jsonPathQuery string, fields []string) error {
+	data, err := toGenericMap(info)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	if jsonPathQuery != "" {
+		result, err = jsonpath.Get(jsonPathQuery, data)
+		if err != nil {
+			return errors.Wrapf(err, "error evaluating --jsonpath %q", jsonPathQuery)
+		}
+	} else {
+		projected := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			value, ok := lookupField(data, field)
+			if !ok {
+				return errors.Errorf("field %q not found in info", field)
+			}
+			projected[field] = value
+		}
+		result = projected
+	}
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// lookupField walks data, a map[string]interface{} tree produced by
+// unmarshaling the info struct's JSON, following the dot-separated path
+// (e.g. "host.kernel"). It reports false if any segment is missing.
+func lookupField(data interface{}, path string) (interface{}, bool) {
+	cur := data
+	for _, segment := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[segment]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
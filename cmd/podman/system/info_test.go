@@ -0,0 +1,65 @@
+package system
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupField(t *testing.T) {
+	data := map[string]interface{}{
+		"host": map[string]interface{}{
+			"kernel": "5.10.0",
+			"arch":   "amd64",
+		},
+		"store": map[string]interface{}{
+			"graphDriverName": "overlay",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		want   interface{}
+		wantOk bool
+	}{
+		{name: "top-level field", path: "store", want: data["store"], wantOk: true},
+		{name: "nested field", path: "host.kernel", want: "5.10.0", wantOk: true},
+		{name: "missing top-level field", path: "bogus", wantOk: false},
+		{name: "missing nested field", path: "host.bogus", wantOk: false},
+		{name: "path through a non-map leaf", path: "host.kernel.bogus", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupField(data, tt.path)
+			if ok != tt.wantOk {
+				t.Fatalf("lookupField(%q) ok = %v, want %v", tt.path, ok, tt.wantOk)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupField(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToGenericMapUsesJSONFieldNames(t *testing.T) {
+	type inner struct {
+		GraphDriverName string `json:"graphDriverName"`
+	}
+	type outer struct {
+		Store inner `json:"store"`
+	}
+
+	data, err := toGenericMap(outer{Store: inner{GraphDriverName: "overlay"}})
+	if err != nil {
+		t.Fatalf("toGenericMap: %v", err)
+	}
+
+	got, ok := lookupField(data, "store.graphDriverName")
+	if !ok {
+		t.Fatalf("expected store.graphDriverName to be present in %v", data)
+	}
+	if got != "overlay" {
+		t.Errorf("store.graphDriverName = %v, want overlay", got)
+	}
+}
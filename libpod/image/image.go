@@ -0,0 +1,116 @@
+package image
+
+import (
+	"context"
+	"io"
+
+	cp "github.com/containers/image/copy"
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/signature"
+	"github.com/containers/image/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultLocalRegistry is the registry prefix implicitly prepended to
+// unqualified names (e.g. "fedora") when they're stored locally.
+const DefaultLocalRegistry = "localhost"
+
+// SigningOptions control whether, and with what key, an image is signed
+// while PushImageToReference copies it to a new destination.
+type SigningOptions struct {
+	RemoveSignatures bool
+	SignBy           string
+}
+
+// DockerRegistryOptions override the registry connection settings
+// PushImageToReference otherwise picks up from the ambient environment.
+type DockerRegistryOptions struct {
+	DockerRegistryCreds         *types.DockerAuthConfig
+	DockerCertPath              string
+	DockerInsecureSkipTLSVerify types.OptionalBool
+}
+
+// Image represents a single image in local container storage.
+type Image struct {
+	id      string
+	names   []string
+	ref     types.ImageReference
+	runtime *Runtime
+}
+
+// ID returns the image's storage ID.
+func (i *Image) ID() string {
+	return i.id
+}
+
+// Names returns every name (repository[:tag]) known to reference this image.
+func (i *Image) Names() []string {
+	return i.names
+}
+
+// GetAdditionalTags parses names into the tagged references
+// PushImageToReference writes into an archive alongside the image's
+// primary name.
+func GetAdditionalTags(names []string) ([]reference.NamedTagged, error) {
+	tags := make([]reference.NamedTagged, 0, len(names))
+	for _, name := range names {
+		ref, err := reference.ParseNormalizedNamed(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing additional tag %q", name)
+		}
+		tagged, ok := reference.TagNameOnly(ref).(reference.NamedTagged)
+		if !ok {
+			return nil, errors.Errorf("%q is not a named, tagged reference", name)
+		}
+		tags = append(tags, tagged)
+	}
+	return tags, nil
+}
+
+// PushImageToReference copies the image to dest, optionally compressing,
+// signing, or authenticating against a registry along the way.
+//
+// sys, when non-nil, is used as both the source and destination
+// SystemContext passed to copy.Image. It exists so callers can steer
+// settings this function has no dedicated parameter for - e.g. the output
+// compression format/level set by `podman save --compress-format` and
+// `--compression-level` - without growing the parameter list further.
+func (i *Image) PushImageToReference(ctx context.Context, dest types.ImageReference, manifestMIMEType, authFile, signaturePolicyPath string, writer io.Writer, forceCompress bool, signingOptions SigningOptions, dockerRegistryOptions *DockerRegistryOptions, additionalDockerArchiveTags []reference.NamedTagged, sys *types.SystemContext) error {
+	systemContext := sys
+	if systemContext == nil {
+		systemContext = &types.SystemContext{}
+	}
+	systemContext.DirForceCompress = systemContext.DirForceCompress || forceCompress
+	if authFile != "" {
+		systemContext.AuthFilePath = authFile
+	}
+	if signaturePolicyPath != "" {
+		systemContext.SignaturePolicyPath = signaturePolicyPath
+	}
+	if dockerRegistryOptions != nil {
+		systemContext.DockerCertPath = dockerRegistryOptions.DockerCertPath
+		systemContext.DockerInsecureSkipTLSVerify = dockerRegistryOptions.DockerInsecureSkipTLSVerify
+		systemContext.DockerAuthConfig = dockerRegistryOptions.DockerRegistryCreds
+	}
+
+	policy, err := signature.DefaultPolicy(systemContext)
+	if err != nil {
+		return errors.Wrap(err, "error loading trust policy")
+	}
+	policyContext, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return errors.Wrap(err, "error building policy context")
+	}
+	defer policyContext.Destroy()
+
+	_, err = cp.Image(ctx, policyContext, dest, i.ref, &cp.Options{
+		ReportWriter:          writer,
+		SourceCtx:             systemContext,
+		DestinationCtx:        systemContext,
+		ForceManifestMIMEType: manifestMIMEType,
+		RemoveSignatures:      signingOptions.RemoveSignatures,
+		SignBy:                signingOptions.SignBy,
+		AdditionalTags:        additionalDockerArchiveTags,
+	})
+	return err
+}
@@ -0,0 +1,35 @@
+package image
+
+import (
+	storageTransport "github.com/containers/image/storage"
+	cstorage "github.com/containers/storage"
+	"github.com/pkg/errors"
+)
+
+// Runtime resolves and manipulates images in a single containers/storage
+// store. libpod.Runtime hands one out via its ImageRuntime method.
+type Runtime struct {
+	store cstorage.Store
+}
+
+// NewRuntime wraps store in an image Runtime.
+func NewRuntime(store cstorage.Store) *Runtime {
+	return &Runtime{store: store}
+}
+
+// NewFromLocal resolves name - an image ID, an unambiguous ID prefix, or one
+// of the full names the image is stored under - against local storage.
+// Digests and short names are not resolved.
+func (r *Runtime) NewFromLocal(name string) (*Image, error) {
+	img, err := r.store.Image(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error looking up local image %q", name)
+	}
+
+	ref, err := storageTransport.Transport.NewStoreReference(r.store, nil, img.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error building image reference for %q", name)
+	}
+
+	return &Image{id: img.ID, names: img.Names, ref: ref, runtime: r}, nil
+}